@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// newCache builds the Cache described by cfg, defaulting to an in-memory
+// cache when no backend is configured.
+func newCache(cfg CacheConfig) (Cache, error) {
+	switch cfg.Backend {
+	case "", "memory":
+		return newMemoryCache(), nil
+	case "bbolt":
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("cache: path is required for backend %q", cfg.Backend)
+		}
+		return newBboltCache(cfg.Path)
+	default:
+		return nil, fmt.Errorf("cache: unknown backend %q", cfg.Backend)
+	}
+}
+
+// CacheStats holds running counters for a Cache, suitable for export as
+// metrics.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+// Cache deduplicates notifications by a hashed key (rules key it by rule name
+// and group), so the same notification isn't re-sent within window.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Seen reports whether key was recorded within window of now, without
+	// modifying the cache.
+	Seen(key string, window time.Duration, now time.Time) bool
+	// Record marks key as seen at now.
+	Record(key string, now time.Time)
+	// Cleanup drops entries older than window, relative to now.
+	Cleanup(window time.Duration, now time.Time)
+	// Stats returns a snapshot of the cache's counters.
+	Stats() CacheStats
+	// Close releases any resources (file handles, connections) held by
+	// the cache.
+	Close() error
+}
+
+// hashKey returns the SHA256 hex digest of a normalized message, so the
+// cache stores fixed-size keys instead of full message strings.
+func hashKey(message string) string {
+	sum := sha256.Sum256([]byte(normalizeMessage(message)))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeMessage trims incidental whitespace so that cosmetically
+// different renderings of the same log line hash identically.
+func normalizeMessage(message string) string {
+	return strings.Join(strings.Fields(message), " ")
+}
+
+// memoryCache is the default, in-process Cache implementation. It loses all
+// state on restart.
+type memoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]time.Time
+	stats   CacheStats
+}
+
+// newMemoryCache creates an empty in-memory cache.
+func newMemoryCache() *memoryCache {
+	return &memoryCache{entries: make(map[string]time.Time)}
+}
+
+func (c *memoryCache) Seen(key string, window time.Duration, now time.Time) bool {
+	c.mu.RLock()
+	ts, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if ok && now.Sub(ts) < window {
+		c.mu.Lock()
+		c.stats.Hits++
+		c.mu.Unlock()
+		return true
+	}
+
+	c.mu.Lock()
+	c.stats.Misses++
+	c.mu.Unlock()
+	return false
+}
+
+func (c *memoryCache) Record(key string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = now
+}
+
+func (c *memoryCache) Cleanup(window time.Duration, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, ts := range c.entries {
+		if now.Sub(ts) > window {
+			delete(c.entries, key)
+			c.stats.Evictions++
+		}
+	}
+}
+
+func (c *memoryCache) Stats() CacheStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	stats := c.stats
+	stats.Size = len(c.entries)
+	return stats
+}
+
+func (c *memoryCache) Close() error {
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var dedupBucket = []byte("dedup")
+
+// bboltCache is a Cache backed by an embedded bbolt database, so the dedup
+// state survives process restarts.
+type bboltCache struct {
+	db *bolt.DB
+
+	mu    sync.Mutex
+	stats CacheStats
+}
+
+// newBboltCache opens (creating if necessary) the bbolt database at path.
+func newBboltCache(path string) (*bboltCache, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("error opening bbolt cache at %s: %v", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(dedupBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error initializing bbolt cache bucket: %v", err)
+	}
+
+	return &bboltCache{db: db}, nil
+}
+
+func (c *bboltCache) Seen(key string, window time.Duration, now time.Time) bool {
+	var seen bool
+	c.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(dedupBucket).Get([]byte(key))
+		if value == nil {
+			return nil
+		}
+		ts := decodeTimestamp(value)
+		seen = now.Sub(ts) < window
+		return nil
+	})
+
+	c.mu.Lock()
+	if seen {
+		c.stats.Hits++
+	} else {
+		c.stats.Misses++
+	}
+	c.mu.Unlock()
+
+	return seen
+}
+
+func (c *bboltCache) Record(key string, now time.Time) {
+	c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(dedupBucket).Put([]byte(key), encodeTimestamp(now))
+	})
+}
+
+func (c *bboltCache) Cleanup(window time.Duration, now time.Time) {
+	var expired [][]byte
+	c.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(dedupBucket).ForEach(func(k, v []byte) error {
+			if now.Sub(decodeTimestamp(v)) > window {
+				expired = append(expired, append([]byte{}, k...))
+			}
+			return nil
+		})
+	})
+	if len(expired) == 0 {
+		return
+	}
+
+	c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(dedupBucket)
+		for _, key := range expired {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	c.mu.Lock()
+	c.stats.Evictions += uint64(len(expired))
+	c.mu.Unlock()
+}
+
+func (c *bboltCache) Stats() CacheStats {
+	c.mu.Lock()
+	stats := c.stats
+	c.mu.Unlock()
+
+	c.db.View(func(tx *bolt.Tx) error {
+		stats.Size = tx.Bucket(dedupBucket).Stats().KeyN
+		return nil
+	})
+	return stats
+}
+
+func (c *bboltCache) Close() error {
+	return c.db.Close()
+}
+
+func encodeTimestamp(t time.Time) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(t.UnixNano()))
+	return buf
+}
+
+func decodeTimestamp(buf []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(buf)))
+}
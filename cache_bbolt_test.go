@@ -0,0 +1,91 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBboltCacheSeenRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedup.db")
+
+	c, err := newBboltCache(path)
+	if err != nil {
+		t.Fatalf("newBboltCache: %v", err)
+	}
+	defer c.Close()
+
+	now := time.Now()
+
+	if c.Seen("a", time.Minute, now) {
+		t.Fatalf("Seen on empty cache = true, want false")
+	}
+
+	c.Record("a", now)
+
+	if !c.Seen("a", time.Minute, now.Add(30*time.Second)) {
+		t.Fatalf("Seen within window = false, want true")
+	}
+	if c.Seen("a", time.Minute, now.Add(2*time.Minute)) {
+		t.Fatalf("Seen after window expired = true, want false")
+	}
+
+	stats := c.Stats()
+	if stats.Hits != 1 || stats.Misses != 2 {
+		t.Fatalf("stats = %+v, want 1 hit and 2 misses", stats)
+	}
+	if stats.Size != 1 {
+		t.Fatalf("Size = %d, want 1", stats.Size)
+	}
+}
+
+func TestBboltCacheCleanup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedup.db")
+
+	c, err := newBboltCache(path)
+	if err != nil {
+		t.Fatalf("newBboltCache: %v", err)
+	}
+	defer c.Close()
+
+	now := time.Now()
+	c.Record("old", now.Add(-time.Hour))
+	c.Record("fresh", now)
+
+	c.Cleanup(time.Minute, now)
+
+	stats := c.Stats()
+	if stats.Size != 1 {
+		t.Fatalf("Size after cleanup = %d, want 1", stats.Size)
+	}
+	if stats.Evictions != 1 {
+		t.Fatalf("Evictions = %d, want 1", stats.Evictions)
+	}
+	if !c.Seen("fresh", time.Minute, now) {
+		t.Fatalf("Cleanup evicted an entry still inside its window")
+	}
+}
+
+func TestBboltCacheSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedup.db")
+	now := time.Now()
+
+	c, err := newBboltCache(path)
+	if err != nil {
+		t.Fatalf("newBboltCache: %v", err)
+	}
+	c.Record("a", now)
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	reopened, err := newBboltCache(path)
+	if err != nil {
+		t.Fatalf("newBboltCache on reopen: %v", err)
+	}
+	defer reopened.Close()
+
+	if !reopened.Seen("a", time.Minute, now.Add(30*time.Second)) {
+		t.Fatalf("key recorded before restart was not seen after reopening the same db file")
+	}
+}
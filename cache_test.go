@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheSeenRecord(t *testing.T) {
+	c := newMemoryCache()
+	now := time.Now()
+
+	if c.Seen("a", time.Minute, now) {
+		t.Fatalf("Seen on empty cache = true, want false")
+	}
+	if stats := c.Stats(); stats.Misses != 1 {
+		t.Fatalf("Misses = %d, want 1", stats.Misses)
+	}
+
+	c.Record("a", now)
+
+	if !c.Seen("a", time.Minute, now.Add(30*time.Second)) {
+		t.Fatalf("Seen within window = false, want true")
+	}
+	if stats := c.Stats(); stats.Hits != 1 {
+		t.Fatalf("Hits = %d, want 1", stats.Hits)
+	}
+
+	if c.Seen("a", time.Minute, now.Add(2*time.Minute)) {
+		t.Fatalf("Seen after window expired = true, want false")
+	}
+}
+
+func TestMemoryCacheCleanup(t *testing.T) {
+	c := newMemoryCache()
+	now := time.Now()
+
+	c.Record("old", now.Add(-time.Hour))
+	c.Record("fresh", now)
+
+	c.Cleanup(time.Minute, now)
+
+	stats := c.Stats()
+	if stats.Size != 1 {
+		t.Fatalf("Size after cleanup = %d, want 1", stats.Size)
+	}
+	if stats.Evictions != 1 {
+		t.Fatalf("Evictions = %d, want 1", stats.Evictions)
+	}
+	if _, ok := c.entries["fresh"]; !ok {
+		t.Fatalf("Cleanup evicted an entry still inside its window")
+	}
+}
+
+func TestHashKeyNormalizesWhitespace(t *testing.T) {
+	a := hashKey("error:  connection   reset")
+	b := hashKey("error: connection reset")
+	if a != b {
+		t.Fatalf("hashKey differed for cosmetically identical messages: %q != %q", a, b)
+	}
+
+	c := hashKey("a different message")
+	if a == c {
+		t.Fatalf("hashKey collided for different messages")
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RetryConfig controls how a notifier retries a failed send.
+type RetryConfig struct {
+	MaxAttempts    int    `yaml:"max_attempts"`
+	InitialBackoff string `yaml:"initial_backoff"`
+	MaxBackoff     string `yaml:"max_backoff"`
+}
+
+// TemplateConfig holds the subject/body templates used to render a notification.
+type TemplateConfig struct {
+	Subject string `yaml:"subject"`
+	Body    string `yaml:"body"`
+}
+
+// NotifierConfig describes a single entry in the `notifiers:` list. Exactly
+// one of the type-specific blocks (Slack, Webhook, Email, Splunk, Discord)
+// should be populated, matching the value of Type.
+type NotifierConfig struct {
+	Type      string   `yaml:"type"`
+	Name      string   `yaml:"name"`
+	Enabled   *bool    `yaml:"enabled"`
+	Severity  []string `yaml:"severity"`
+	RateLimit string   `yaml:"rate_limit"`
+
+	Retry    RetryConfig    `yaml:"retry"`
+	Template TemplateConfig `yaml:"template"`
+
+	Slack   *SlackNotifierConfig   `yaml:"slack,omitempty"`
+	Webhook *WebhookNotifierConfig `yaml:"webhook,omitempty"`
+	Email   *EmailNotifierConfig   `yaml:"email,omitempty"`
+	Splunk  *SplunkNotifierConfig  `yaml:"splunk,omitempty"`
+	Discord *DiscordNotifierConfig `yaml:"discord,omitempty"`
+}
+
+// enabled reports whether the notifier is active, defaulting to true when
+// unset so existing configs keep working without an explicit flag.
+func (n NotifierConfig) enabled() bool {
+	return n.Enabled == nil || *n.Enabled
+}
+
+// RuleConfig describes one entry in the `rules:` list. Each rule owns its
+// own LogQL query, evaluates on its own interval, and maintains its own
+// dedup/throttle state, independent of every other rule.
+type RuleConfig struct {
+	Name     string   `yaml:"name"`
+	Query    string   `yaml:"query"`
+	Patterns []string `yaml:"patterns"`
+	Interval string   `yaml:"interval"`
+
+	// DedupWindow suppresses repeat firings for the same group for this
+	// long after the rule last fired.
+	DedupWindow string `yaml:"dedup_window"`
+
+	// For requires ForCount matches within this window before the rule
+	// fires, mirroring Prometheus/Loki ruler "for" semantics.
+	For      string `yaml:"for"`
+	ForCount int    `yaml:"for_count"`
+
+	// GroupBy lists the stream labels used to bucket matches so that N
+	// similar errors become a single, counted notification.
+	GroupBy []string `yaml:"group_by"`
+}
+
+// CacheConfig selects the dedup cache backend.
+type CacheConfig struct {
+	Backend string `yaml:"backend"`
+	Path    string `yaml:"path"`
+}
+
+// MetricsConfig controls the Prometheus metrics / health-check HTTP server.
+type MetricsConfig struct {
+	Listen string `yaml:"listen"`
+}
+
+// LoggingConfig controls the structured logger.
+type LoggingConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"` // json (default) or console
+}
+
+type Config struct {
+	Cache   CacheConfig   `yaml:"cache"`
+	Metrics MetricsConfig `yaml:"metrics"`
+	Logging LoggingConfig `yaml:"logging"`
+	Loki    struct {
+		Endpoint string `yaml:"endpoint" env:"LOKI_ENDPOINT"`
+		Query    string `yaml:"query" env:"LOKI_QUERY"`
+		Pattern  string `yaml:"pattern" env:"LOKI_PATTERN"`
+		Interval string `yaml:"interval" env:"LOKI_INTERVAL"`
+		// Mode is "poll" (default, queries on an interval) or "tail"
+		// (streams Loki's websocket tail endpoint in real time).
+		Mode string `yaml:"mode" env:"LOKI_MODE"`
+	} `yaml:"loki"`
+	Slack struct {
+		Token   string `yaml:"token" env:"SLACK_TOKEN"`
+		Channel string `yaml:"channel" env:"SLACK_CHANNEL"`
+	} `yaml:"slack"`
+	Notifiers []NotifierConfig `yaml:"notifiers"`
+	Rules     []RuleConfig     `yaml:"rules"`
+}
+
+func loadConfig(path string) (*Config, error) {
+	config := &Config{}
+
+	// Set default values
+	config.Loki.Endpoint = "http://localhost:3100"
+	config.Loki.Query = "{job=\"your-job-name\"}"
+	config.Loki.Pattern = "error|exception|critical"
+	config.Loki.Interval = "1m"
+	config.Loki.Mode = "poll"
+	config.Metrics.Listen = ":9090"
+	config.Logging.Level = "info"
+	config.Logging.Format = "json"
+
+	// Try to load from config file if it exists
+	if _, err := os.Stat(path); err == nil {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading config file: %v", err)
+		}
+
+		err = yaml.Unmarshal(data, config)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing config file: %v", err)
+		}
+	}
+
+	// Override with environment variables if they exist
+	if env := os.Getenv("LOKI_ENDPOINT"); env != "" {
+		config.Loki.Endpoint = env
+	}
+	if env := os.Getenv("LOKI_QUERY"); env != "" {
+		config.Loki.Query = env
+	}
+	if env := os.Getenv("LOKI_PATTERN"); env != "" {
+		config.Loki.Pattern = env
+	}
+	if env := os.Getenv("LOKI_INTERVAL"); env != "" {
+		config.Loki.Interval = env
+	}
+	if env := os.Getenv("LOKI_MODE"); env != "" {
+		config.Loki.Mode = env
+	}
+	if env := os.Getenv("SLACK_TOKEN"); env != "" {
+		config.Slack.Token = env
+	}
+	if env := os.Getenv("SLACK_CHANNEL"); env != "" {
+		config.Slack.Channel = env
+	}
+
+	// Fall back to a single Slack notifier built from the legacy `slack:`
+	// block if no `notifiers:` list was configured, so existing configs
+	// keep working unchanged.
+	if len(config.Notifiers) == 0 && config.Slack.Token != "" {
+		config.Notifiers = append(config.Notifiers, NotifierConfig{
+			Type: "slack",
+			Name: "slack-default",
+			Slack: &SlackNotifierConfig{
+				Token:   config.Slack.Token,
+				Channel: config.Slack.Channel,
+			},
+		})
+	}
+
+	// Fall back to a single rule built from the legacy `loki:` block if no
+	// `rules:` list was configured, so existing configs keep working.
+	if len(config.Rules) == 0 {
+		config.Rules = append(config.Rules, RuleConfig{
+			Name:     "default",
+			Query:    config.Loki.Query,
+			Patterns: []string{config.Loki.Pattern},
+			Interval: config.Loki.Interval,
+		})
+	}
+
+	// Validate required fields
+	if config.Slack.Token == "" && len(config.Notifiers) == 0 {
+		return nil, fmt.Errorf("SLACK_TOKEN is required")
+	}
+	if config.Slack.Token != "" && config.Slack.Channel == "" {
+		return nil, fmt.Errorf("SLACK_CHANNEL is required")
+	}
+
+	return config, nil
+}
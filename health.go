@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// healthState tracks the most recent successful Loki query across all
+// rules, so /healthz and /readyz can report liveness and readiness.
+type healthState struct {
+	mu          sync.RWMutex
+	lastSuccess time.Time
+}
+
+// recordSuccess marks t as the most recent successful Loki query time.
+func (h *healthState) recordSuccess(t time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastSuccess = t
+}
+
+// lastSuccessAt returns the last time recordSuccess was called.
+func (h *healthState) lastSuccessAt() time.Time {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.lastSuccess
+}
+
+// healthzHandler always reports ok: the process is alive and serving.
+func (h *healthState) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// readyzHandler reports ready once at least one Loki query has succeeded.
+func (h *healthState) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	last := h.lastSuccessAt()
+	if last.IsZero() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "not ready"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status":       "ok",
+		"last_success": last.Format(time.RFC3339),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
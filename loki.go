@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/loki/pkg/logcli/client"
+	"github.com/grafana/loki/pkg/logcli/query"
+)
+
+// logEntry is a single log line returned by Loki, together with the stream
+// labels it was tagged with.
+type logEntry struct {
+	Line      string
+	Timestamp time.Time
+	Labels    map[string]string
+}
+
+// queryLoki runs logql against endpoint over [since, until) and returns every
+// log line in the result, labels included. It returns ctx.Err() as soon as
+// ctx is cancelled, even though logcli's client has no context of its own to
+// cancel the underlying HTTP call: the call keeps running in the background
+// until it completes or Loki times it out, but the caller is freed to return
+// immediately, which is what keeps shutdown bounded.
+func queryLoki(ctx context.Context, endpoint, logql string, since, until time.Time) ([]logEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		entries []logEntry
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		entries, err := doQueryLoki(endpoint, logql, since, until)
+		done <- result{entries, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-done:
+		return res.entries, res.err
+	}
+}
+
+// doQueryLoki performs the blocking Loki query call.
+func doQueryLoki(endpoint, logql string, since, until time.Time) ([]logEntry, error) {
+	c := client.New(endpoint, nil)
+	q := query.NewQuery(logql, since, until, 0, 0, false, false, false)
+
+	results, err := c.Query(q)
+	if err != nil {
+		return nil, fmt.Errorf("error querying Loki: %v", err)
+	}
+
+	var entries []logEntry
+	for _, stream := range results.Data.Result {
+		for _, value := range stream.Values {
+			ts, err := parseLokiTimestamp(value[0])
+			if err != nil {
+				ts = time.Now()
+			}
+			entries = append(entries, logEntry{
+				Line:      value[1],
+				Timestamp: ts,
+				Labels:    stream.Labels,
+			})
+		}
+	}
+
+	return entries, nil
+}
+
+// parseLokiTimestamp converts the nanosecond-epoch string Loki returns for
+// each log line into a time.Time.
+func parseLokiTimestamp(s string) (time.Time, error) {
+	var sec, nsec int64
+	if _, err := fmt.Sscanf(s, "%d", &nsec); err != nil {
+		return time.Time{}, err
+	}
+	sec = nsec / int64(time.Second)
+	nsec = nsec % int64(time.Second)
+	return time.Unix(sec, nsec), nil
+}
@@ -2,185 +2,98 @@ package main
 
 import (
 	"context"
-	"flag"
-	"fmt"
-	"log"
 	"os"
-	"regexp"
+	"os/signal"
 	"sync"
+	"syscall"
 	"time"
 
-	"github.com/grafana/loki/pkg/logcli/client"
-	"github.com/grafana/loki/pkg/logcli/query"
-	"github.com/slack-go/slack"
-	"gopkg.in/yaml.v3"
-)
-
-// MessageCache represents a cache of recently sent messages
-type MessageCache struct {
-	messages map[string]time.Time
-	mu       sync.RWMutex
-	window   time.Duration
-}
+	"flag"
 
-// NewMessageCache creates a new message cache with the specified time window
-func NewMessageCache(window time.Duration) *MessageCache {
-	return &MessageCache{
-		messages: make(map[string]time.Time),
-		window:   window,
-	}
-}
+	"github.com/rs/zerolog/log"
+)
 
-// Add adds a message to the cache
-func (c *MessageCache) Add(message string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.messages[message] = time.Now()
+// generation is one live set of notifiers and rules, running under its own
+// cancellable context so a SIGHUP reload can tear it down and start a fresh
+// one without restarting the process or losing the dedup cache.
+type generation struct {
+	dispatcher *dispatcher
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
 }
 
-// Contains checks if a message is in the cache and not expired
-func (c *MessageCache) Contains(message string) bool {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	
-	if timestamp, exists := c.messages[message]; exists {
-		if time.Since(timestamp) < c.window {
-			return true
-		}
-		// Message exists but is expired, remove it
-		c.mu.RUnlock()
-		c.mu.Lock()
-		delete(c.messages, message)
-		c.mu.Unlock()
-		c.mu.RLock()
-	}
-	return false
-}
+// startGeneration compiles cfg's notifiers and rules and starts one
+// goroutine per rule under ctx, sharing cache and health across reloads.
+func startGeneration(ctx context.Context, cfg *Config, cache Cache, health *healthState) (*generation, error) {
+	genCtx, cancel := context.WithCancel(ctx)
 
-// Cleanup removes expired messages from the cache
-func (c *MessageCache) Cleanup() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	
-	now := time.Now()
-	for message, timestamp := range c.messages {
-		if now.Sub(timestamp) > c.window {
-			delete(c.messages, message)
-		}
+	d, err := newDispatcher(cfg.Notifiers)
+	if err != nil {
+		cancel()
+		return nil, err
 	}
-}
-
-type Config struct {
-	Loki struct {
-		Endpoint string `yaml:"endpoint" env:"LOKI_ENDPOINT"`
-		Query    string `yaml:"query" env:"LOKI_QUERY"`
-		Pattern  string `yaml:"pattern" env:"LOKI_PATTERN"`
-		Interval string `yaml:"interval" env:"LOKI_INTERVAL"`
-	} `yaml:"loki"`
-	Slack struct {
-		Token   string `yaml:"token" env:"SLACK_TOKEN"`
-		Channel string `yaml:"channel" env:"SLACK_CHANNEL"`
-	} `yaml:"slack"`
-}
 
-func loadConfig(path string) (*Config, error) {
-	config := &Config{}
-
-	// Set default values
-	config.Loki.Endpoint = "http://localhost:3100"
-	config.Loki.Query = "{job=\"your-job-name\"}"
-	config.Loki.Pattern = "error|exception|critical"
-	config.Loki.Interval = "1m"
-
-	// Try to load from config file if it exists
-	if _, err := os.Stat(path); err == nil {
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return nil, fmt.Errorf("error reading config file: %v", err)
-		}
-
-		err = yaml.Unmarshal(data, config)
+	rules := make([]*compiledRule, 0, len(cfg.Rules))
+	for _, ruleCfg := range cfg.Rules {
+		rule, err := compileRule(ruleCfg, cache)
 		if err != nil {
-			return nil, fmt.Errorf("error parsing config file: %v", err)
+			cancel()
+			return nil, err
 		}
+		rules = append(rules, rule)
 	}
 
-	// Override with environment variables if they exist
-	if env := os.Getenv("LOKI_ENDPOINT"); env != "" {
-		config.Loki.Endpoint = env
-	}
-	if env := os.Getenv("LOKI_QUERY"); env != "" {
-		config.Loki.Query = env
-	}
-	if env := os.Getenv("LOKI_PATTERN"); env != "" {
-		config.Loki.Pattern = env
-	}
-	if env := os.Getenv("LOKI_INTERVAL"); env != "" {
-		config.Loki.Interval = env
-	}
-	if env := os.Getenv("SLACK_TOKEN"); env != "" {
-		config.Slack.Token = env
-	}
-	if env := os.Getenv("SLACK_CHANNEL"); env != "" {
-		config.Slack.Channel = env
-	}
+	g := &generation{dispatcher: d, cancel: cancel}
 
-	// Validate required fields
-	if config.Slack.Token == "" {
-		return nil, fmt.Errorf("SLACK_TOKEN is required")
-	}
-	if config.Slack.Channel == "" {
-		return nil, fmt.Errorf("SLACK_CHANNEL is required")
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		runCacheCleanup(genCtx, cache, maxDedupWindow(cfg), rules)
+	}()
+
+	for _, rule := range rules {
+		g.wg.Add(1)
+		go func(rule *compiledRule) {
+			defer g.wg.Done()
+			log.Info().Str("rule", rule.cfg.Name).Str("query", rule.cfg.Query).Str("interval", rule.cfg.Interval).Str("mode", cfg.Loki.Mode).Msg("watching rule")
+			rule.run(genCtx, cfg.Loki.Endpoint, cfg.Loki.Mode, d, health)
+		}(rule)
 	}
 
-	return config, nil
+	return g, nil
 }
 
-func queryLoki(cfg *Config, pattern *regexp.Regexp) ([]string, error) {
-	client := client.New(cfg.Loki.Endpoint, nil)
-	q := query.NewQuery(cfg.Loki.Query, time.Now().Add(-time.Minute), time.Now(), 0, 0, false, false, false)
-
-	results, err := client.Query(q)
-	if err != nil {
-		return nil, fmt.Errorf("error querying Loki: %v", err)
-	}
-
-	var matches []string
-	for _, stream := range results.Data.Result {
-		for _, value := range stream.Values {
-			if pattern.MatchString(value[1]) {
-				matches = append(matches, fmt.Sprintf("Found pattern in log: %s", value[1]))
+// runCacheCleanup periodically drops dedup entries older than window, and
+// each rule's stale group_by buckets, so neither grows without bound, until
+// ctx is cancelled. It runs scoped to one generation so a SIGHUP reload that
+// changes rule dedup windows restarts it with the new window instead of
+// racing the outer config variable.
+func runCacheCleanup(ctx context.Context, cache Cache, window time.Duration, rules []*compiledRule) {
+	ticker := time.NewTicker(15 * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			cache.Cleanup(window, now)
+			for _, rule := range rules {
+				rule.pruneStaleGroups(now)
 			}
 		}
 	}
-
-	return matches, nil
 }
 
-func sendSlackNotification(cfg *Config, messages []string, cache *MessageCache) error {
-	api := slack.New(cfg.Slack.Token)
-	
-	for _, msg := range messages {
-		// Check if message was recently sent
-		if cache.Contains(msg) {
-			log.Printf("Skipping duplicate message: %s", msg)
-			continue
-		}
-
-		_, _, err := api.PostMessage(
-			cfg.Slack.Channel,
-			slack.MsgOptionText(msg, false),
-		)
-		if err != nil {
-			return fmt.Errorf("error sending Slack message: %v", err)
-		}
+// stop cancels the generation's rules and waits for them to exit, then
+// drains any notifications still in flight.
+func (g *generation) stop(drainTimeout time.Duration) {
+	g.cancel()
+	g.wg.Wait()
 
-		// Add message to cache after successful send
-		cache.Add(msg)
-		log.Printf("Sent message to Slack: %s", msg)
-	}
-	
-	return nil
+	drainCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	g.dispatcher.Shutdown(drainCtx)
 }
 
 func main() {
@@ -189,53 +102,72 @@ func main() {
 
 	cfg, err := loadConfig(*configPath)
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		log.Fatal().Err(err).Msg("failed to load config")
 	}
+	configureLogging(cfg.Logging)
 
-	pattern, err := regexp.Compile(cfg.Loki.Pattern)
+	cache, err := newCache(cfg.Cache)
 	if err != nil {
-		log.Fatalf("Failed to compile pattern: %v", err)
+		log.Fatal().Err(err).Msg("failed to configure dedup cache")
 	}
+	defer cache.Close()
+	registerCacheMetrics(cache)
 
-	interval, err := time.ParseDuration(cfg.Loki.Interval)
-	if err != nil {
-		log.Fatalf("Failed to parse interval: %v", err)
-	}
+	health := &healthState{}
+	serveMetrics(cfg.Metrics.Listen, health)
 
-	// Create message cache with 1 hour window
-	messageCache := NewMessageCache(1 * time.Hour)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	// Start cache cleanup goroutine
-	go func() {
-		ticker := time.NewTicker(15 * time.Minute)
-		defer ticker.Stop()
-		for range ticker.C {
-			messageCache.Cleanup()
-		}
-	}()
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
 
-	log.Printf("Starting Loki pattern monitor...")
-	log.Printf("Monitoring pattern: %s", cfg.Loki.Pattern)
-	log.Printf("Check interval: %s", interval)
-
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	gen, err := startGeneration(ctx, cfg, cache, health)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to start")
+	}
+	log.Info().Int("rules", len(cfg.Rules)).Msg("starting Loki pattern monitor")
 
 	for {
 		select {
-		case <-ticker.C:
-			matches, err := queryLoki(cfg, pattern)
+		case <-ctx.Done():
+			log.Info().Msg("shutting down")
+			gen.stop(10 * time.Second)
+			return
+
+		case <-reload:
+			log.Info().Str("config", *configPath).Msg("SIGHUP received, reloading config")
+			newCfg, err := loadConfig(*configPath)
 			if err != nil {
-				log.Printf("Error querying Loki: %v", err)
+				log.Error().Err(err).Msg("config reload failed, keeping current config")
 				continue
 			}
 
-			if len(matches) > 0 {
-				err = sendSlackNotification(cfg, matches, messageCache)
-				if err != nil {
-					log.Printf("Error sending Slack notification: %v", err)
-				}
+			newGen, err := startGeneration(ctx, newCfg, cache, health)
+			if err != nil {
+				log.Error().Err(err).Msg("config reload failed, keeping current config")
+				continue
 			}
+
+			gen.stop(10 * time.Second)
+			cfg = newCfg
+			gen = newGen
+			configureLogging(cfg.Logging)
+			log.Info().Int("rules", len(cfg.Rules)).Msg("reloaded config")
 		}
 	}
-} 
\ No newline at end of file
+}
+
+// maxDedupWindow returns the longest dedup_window configured across all
+// rules, but never less than an hour, so the periodic cache sweep never
+// evicts an entry a rule still needs.
+func maxDedupWindow(cfg *Config) time.Duration {
+	max := time.Hour
+	for _, rule := range cfg.Rules {
+		if d := parseDurationOrDefault(rule.DedupWindow, time.Hour); d > max {
+			max = d
+		}
+	}
+	return max
+}
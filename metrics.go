@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	lokiQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "loki_query_duration_seconds",
+		Help: "Time spent querying Loki, per rule.",
+	}, []string{"rule"})
+
+	lokiQueryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_query_errors_total",
+		Help: "Total number of failed Loki queries, per rule.",
+	}, []string{"rule"})
+
+	patternMatches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pattern_matches_total",
+		Help: "Total number of log lines matching a rule's pattern, per rule and severity.",
+	}, []string{"rule", "severity"})
+
+	notificationsSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notifications_sent_total",
+		Help: "Total number of notifications successfully delivered, per sink.",
+	}, []string{"sink"})
+
+	notificationsFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notifications_failed_total",
+		Help: "Total number of notifications that failed delivery, per sink.",
+	}, []string{"sink"})
+)
+
+// registerCacheMetrics exposes cache's running counters as Prometheus
+// gauges, sampled on every scrape.
+func registerCacheMetrics(cache Cache) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "dedup_cache_size",
+		Help: "Current number of entries held in the dedup cache.",
+	}, func() float64 {
+		return float64(cache.Stats().Size)
+	})
+
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "dedup_cache_hits_total",
+		Help: "Cumulative number of dedup cache lookups that found a recent match.",
+	}, func() float64 {
+		return float64(cache.Stats().Hits)
+	})
+
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "dedup_cache_misses_total",
+		Help: "Cumulative number of dedup cache lookups that found no recent match.",
+	}, func() float64 {
+		return float64(cache.Stats().Misses)
+	})
+
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "dedup_cache_evictions_total",
+		Help: "Cumulative number of dedup cache entries dropped by cleanup.",
+	}, func() float64 {
+		return float64(cache.Stats().Evictions)
+	})
+}
+
+// serveMetrics starts the /metrics, /healthz and /readyz HTTP server in the
+// background. It is a no-op when listen is empty.
+func serveMetrics(listen string, health *healthState) {
+	if listen == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", health.healthzHandler)
+	mux.HandleFunc("/readyz", health.readyzHandler)
+
+	go func() {
+		if err := http.ListenAndServe(listen, mux); err != nil {
+			log.Error().Err(err).Msg("metrics server stopped")
+		}
+	}()
+}
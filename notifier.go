@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Notification is a single pattern match routed to one or more notifiers.
+type Notification struct {
+	Rule      string
+	Query     string
+	Message   string
+	Severity  string
+	Timestamp time.Time
+	Labels    map[string]string
+}
+
+// Notifier delivers a Notification to an external destination (chat, email,
+// webhook, ...). Implementations are expected to be safe for concurrent use.
+type Notifier interface {
+	// Name identifies the notifier instance in logs and metrics.
+	Name() string
+	// Send delivers the notification, returning an error if delivery failed.
+	Send(ctx context.Context, n Notification) error
+}
+
+// newNotifier builds the Notifier described by cfg.
+func newNotifier(cfg NotifierConfig) (Notifier, error) {
+	switch cfg.Type {
+	case "slack":
+		if cfg.Slack == nil {
+			return nil, fmt.Errorf("notifier %q: slack config is required for type slack", cfg.Name)
+		}
+		return newSlackNotifier(cfg.Name, cfg.Slack, cfg.Template)
+	case "webhook":
+		if cfg.Webhook == nil {
+			return nil, fmt.Errorf("notifier %q: webhook config is required for type webhook", cfg.Name)
+		}
+		return newWebhookNotifier(cfg.Name, cfg.Webhook, cfg.Template), nil
+	case "email":
+		if cfg.Email == nil {
+			return nil, fmt.Errorf("notifier %q: email config is required for type email", cfg.Name)
+		}
+		return newEmailNotifier(cfg.Name, cfg.Email, cfg.Template), nil
+	case "splunk":
+		if cfg.Splunk == nil {
+			return nil, fmt.Errorf("notifier %q: splunk config is required for type splunk", cfg.Name)
+		}
+		return newSplunkNotifier(cfg.Name, cfg.Splunk, cfg.Template), nil
+	case "discord":
+		if cfg.Discord == nil {
+			return nil, fmt.Errorf("notifier %q: discord config is required for type discord", cfg.Name)
+		}
+		return newDiscordNotifier(cfg.Name, cfg.Discord, cfg.Template), nil
+	default:
+		return nil, fmt.Errorf("notifier %q: unknown type %q", cfg.Name, cfg.Type)
+	}
+}
+
+// sendTimeout bounds a single notifier delivery, retries included. Sends run
+// on a context derived from context.Background() rather than the caller's
+// ctx, so cancelling the rule-processing context that queued a notification
+// (e.g. on shutdown or SIGHUP reload) doesn't also abort delivery in flight.
+// The email notifier does not observe ctx, so for it this bounds only the
+// waits between retries, not an SMTP exchange already in progress.
+const sendTimeout = 30 * time.Second
+
+// dispatcher fans a Notification out to every enabled notifier, applying each
+// notifier's severity filter and rate limit, and retrying failed sends with
+// exponential backoff.
+type dispatcher struct {
+	entries []dispatchEntry
+	wg      sync.WaitGroup
+}
+
+type dispatchEntry struct {
+	notifier Notifier
+	cfg      NotifierConfig
+	limiter  *rateLimiter
+}
+
+// newDispatcher builds the set of notifiers described by cfgs, skipping
+// disabled entries.
+func newDispatcher(cfgs []NotifierConfig) (*dispatcher, error) {
+	d := &dispatcher{}
+	for _, cfg := range cfgs {
+		if !cfg.enabled() {
+			continue
+		}
+		n, err := newNotifier(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		var limiter *rateLimiter
+		if cfg.RateLimit != "" {
+			interval, err := time.ParseDuration(cfg.RateLimit)
+			if err != nil {
+				return nil, fmt.Errorf("notifier %q: invalid rate_limit: %v", cfg.Name, err)
+			}
+			limiter = newRateLimiter(interval)
+		}
+
+		d.entries = append(d.entries, dispatchEntry{notifier: n, cfg: cfg, limiter: limiter})
+	}
+	return d, nil
+}
+
+// Dispatch sends n to every notifier whose severity filter matches,
+// logging (but not aborting on) per-sink failures.
+func (d *dispatcher) Dispatch(n Notification) {
+	for _, entry := range d.entries {
+		if !severityAllowed(entry.cfg.Severity, n.Severity) {
+			continue
+		}
+		if entry.limiter != nil && !entry.limiter.Allow() {
+			log.Warn().Str("notifier", entry.notifier.Name()).Msg("rate limit exceeded, dropping notification")
+			continue
+		}
+
+		entry := entry
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			sendCtx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+			defer cancel()
+			if err := sendWithRetry(sendCtx, entry.cfg.Retry, func() error {
+				return entry.notifier.Send(sendCtx, n)
+			}); err != nil {
+				notificationsFailed.WithLabelValues(entry.notifier.Name()).Inc()
+				log.Error().Err(err).Str("notifier", entry.notifier.Name()).Msg("error sending notification")
+				return
+			}
+			notificationsSent.WithLabelValues(entry.notifier.Name()).Inc()
+			log.Info().Str("notifier", entry.notifier.Name()).Str("message", n.Message).Msg("sent notification")
+		}()
+	}
+}
+
+// Shutdown waits for every in-flight Send to finish, or for ctx to be
+// cancelled, whichever comes first.
+func (d *dispatcher) Shutdown(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Warn().Msg("timed out waiting for in-flight notifications to drain")
+	}
+}
+
+// severityAllowed reports whether severity passes the filter list. An empty
+// filter allows everything.
+func severityAllowed(filter []string, severity string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, s := range filter {
+		if s == severity {
+			return true
+		}
+	}
+	return false
+}
+
+// sendWithRetry calls fn, retrying with exponential backoff (plus jitter) per
+// cfg until it succeeds, attempts are exhausted, or ctx is cancelled.
+func sendWithRetry(ctx context.Context, cfg RetryConfig, fn func() error) error {
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	initial := parseDurationOrDefault(cfg.InitialBackoff, time.Second)
+	max := parseDurationOrDefault(cfg.MaxBackoff, 30*time.Second)
+
+	backoff := initial
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		lastErr = fn()
+		if lastErr == nil {
+			return nil
+		}
+		if attempt == maxAttempts {
+			break
+		}
+
+		sleep := backoff + time.Duration(rand.Int63n(int64(backoff)/2+1))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sleep):
+		}
+
+		backoff *= 2
+		if backoff > max {
+			backoff = max
+		}
+	}
+	return fmt.Errorf("giving up after %d attempts: %v", maxAttempts, lastErr)
+}
+
+func parseDurationOrDefault(s string, def time.Duration) time.Duration {
+	if s == "" {
+		return def
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return def
+	}
+	return d
+}
+
+// rateLimiter drops events that arrive before interval has elapsed since the
+// last accepted event.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     time.Time
+}
+
+func newRateLimiter(interval time.Duration) *rateLimiter {
+	return &rateLimiter{interval: interval}
+}
+
+// Allow reports whether an event may proceed now, recording it if so.
+func (r *rateLimiter) Allow() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if !r.last.IsZero() && now.Sub(r.last) < r.interval {
+		return false
+	}
+	r.last = now
+	return true
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DiscordNotifierConfig holds the settings for a `type: discord` notifier
+// that posts to a Discord incoming webhook.
+type DiscordNotifierConfig struct {
+	WebhookURL string `yaml:"webhook_url"`
+	Username   string `yaml:"username"`
+}
+
+type discordNotifier struct {
+	name     string
+	cfg      *DiscordNotifierConfig
+	template TemplateConfig
+	client   *http.Client
+}
+
+func newDiscordNotifier(name string, cfg *DiscordNotifierConfig, template TemplateConfig) *discordNotifier {
+	return &discordNotifier{
+		name:     name,
+		cfg:      cfg,
+		template: template,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (d *discordNotifier) Name() string {
+	return d.name
+}
+
+func (d *discordNotifier) Send(ctx context.Context, n Notification) error {
+	content, err := renderTemplate(d.template.Body, "{{.Message}}", n)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"content":  content,
+		"username": d.cfg.Username,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling discord payload: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.cfg.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("error building discord request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending discord message: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("discord webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
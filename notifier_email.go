@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+// EmailNotifierConfig holds the settings for a `type: email` notifier that
+// sends alerts over SMTP.
+type EmailNotifierConfig struct {
+	Host     string   `yaml:"host"`
+	Port     int      `yaml:"port"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+}
+
+type emailNotifier struct {
+	name     string
+	cfg      *EmailNotifierConfig
+	template TemplateConfig
+}
+
+func newEmailNotifier(name string, cfg *EmailNotifierConfig, template TemplateConfig) *emailNotifier {
+	return &emailNotifier{name: name, cfg: cfg, template: template}
+}
+
+func (e *emailNotifier) Name() string {
+	return e.name
+}
+
+func (e *emailNotifier) Send(ctx context.Context, n Notification) error {
+	subject, err := renderTemplate(e.template.Subject, "[{{.Severity}}] pattern match on {{.Rule}}", n)
+	if err != nil {
+		return err
+	}
+	body, err := renderTemplate(e.template.Body, "{{.Message}}", n)
+	if err != nil {
+		return err
+	}
+
+	addr := fmt.Sprintf("%s:%d", e.cfg.Host, e.cfg.Port)
+	var auth smtp.Auth
+	if e.cfg.Username != "" {
+		auth = smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)
+	}
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n",
+		e.cfg.From, strings.Join(e.cfg.To, ", "), subject, body)
+
+	if err := smtp.SendMail(addr, auth, e.cfg.From, e.cfg.To, []byte(msg)); err != nil {
+		return fmt.Errorf("error sending email: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/slack-go/slack"
+)
+
+const defaultAttachmentColor = "#cccccc"
+
+// SeverityColorRule maps a regex tested against the matched message to a
+// Slack attachment color (e.g. "good"/"warning"/"danger" or a hex code).
+type SeverityColorRule struct {
+	Regex string `yaml:"regex"`
+	Color string `yaml:"color"`
+}
+
+// SlackNotifierConfig holds the settings for a `type: slack` notifier.
+type SlackNotifierConfig struct {
+	Token       string              `yaml:"token"`
+	Channel     string              `yaml:"channel"`
+	GrafanaURL  string              `yaml:"grafana_url"`
+	SeverityMap []SeverityColorRule `yaml:"severity_map"`
+	IconURL     string              `yaml:"icon_url"`
+	IconEmoji   string              `yaml:"icon_emoji"`
+	Username    string              `yaml:"username"`
+}
+
+type compiledSeverityRule struct {
+	pattern *regexp.Regexp
+	color   string
+}
+
+// slackNotifier posts matched log lines to a Slack channel as a rich,
+// severity-colored attachment.
+type slackNotifier struct {
+	name        string
+	cfg         *SlackNotifierConfig
+	template    TemplateConfig
+	api         *slack.Client
+	severityMap []compiledSeverityRule
+}
+
+func newSlackNotifier(name string, cfg *SlackNotifierConfig, template TemplateConfig) (*slackNotifier, error) {
+	n := &slackNotifier{
+		name:     name,
+		cfg:      cfg,
+		template: template,
+		api:      slack.New(cfg.Token),
+	}
+
+	for _, rule := range cfg.SeverityMap {
+		pattern, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("notifier %q: invalid severity_map regex %q: %v", name, rule.Regex, err)
+		}
+		n.severityMap = append(n.severityMap, compiledSeverityRule{pattern: pattern, color: rule.Color})
+	}
+
+	return n, nil
+}
+
+func (s *slackNotifier) Name() string {
+	return s.name
+}
+
+func (s *slackNotifier) Send(ctx context.Context, n Notification) error {
+	body, err := renderTemplate(s.template.Body, "{{.Message}}", n)
+	if err != nil {
+		return err
+	}
+
+	attachment := slack.Attachment{
+		Color:  s.colorFor(n.Message),
+		Text:   body,
+		Fields: s.fieldsFor(n),
+	}
+	if s.cfg.GrafanaURL != "" && n.Query != "" {
+		attachment.Title = "View in Grafana"
+		attachment.TitleLink = s.grafanaExploreURL(n.Query)
+	}
+
+	opts := []slack.MsgOption{slack.MsgOptionAttachments(attachment)}
+	if s.cfg.IconURL != "" {
+		opts = append(opts, slack.MsgOptionIconURL(s.cfg.IconURL))
+	}
+	if s.cfg.IconEmoji != "" {
+		opts = append(opts, slack.MsgOptionIconEmoji(s.cfg.IconEmoji))
+	}
+	if s.cfg.Username != "" {
+		opts = append(opts, slack.MsgOptionUsername(s.cfg.Username))
+	}
+
+	_, _, err = s.api.PostMessageContext(ctx, s.cfg.Channel, opts...)
+	return err
+}
+
+// colorFor returns the attachment sidebar color for message, using the first
+// matching rule in severity_map, or a neutral gray if none match.
+func (s *slackNotifier) colorFor(message string) string {
+	for _, rule := range s.severityMap {
+		if rule.pattern.MatchString(message) {
+			return rule.color
+		}
+	}
+	return defaultAttachmentColor
+}
+
+// fieldsFor builds the attachment fields from the labels parsed off the
+// Loki stream, in a fixed, readable order.
+func (s *slackNotifier) fieldsFor(n Notification) []slack.AttachmentField {
+	var fields []slack.AttachmentField
+	for _, key := range []string{"job", "instance", "level"} {
+		if value, ok := n.Labels[key]; ok && value != "" {
+			fields = append(fields, slack.AttachmentField{Title: key, Value: value, Short: true})
+		}
+	}
+	if !n.Timestamp.IsZero() {
+		fields = append(fields, slack.AttachmentField{
+			Title: "timestamp",
+			Value: n.Timestamp.Format("2006-01-02T15:04:05Z07:00"),
+			Short: true,
+		})
+	}
+	return fields
+}
+
+// grafanaExploreURL builds a link into Grafana Explore prefilled with query.
+func (s *slackNotifier) grafanaExploreURL(query string) string {
+	return fmt.Sprintf("%s/explore?left=%s", s.cfg.GrafanaURL, url.QueryEscape(query))
+}
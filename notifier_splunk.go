@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SplunkNotifierConfig holds the settings for a `type: splunk` notifier that
+// forwards matches to a Splunk HTTP Event Collector (HEC).
+type SplunkNotifierConfig struct {
+	URL        string `yaml:"url"`
+	Token      string `yaml:"token"`
+	Index      string `yaml:"index"`
+	SourceType string `yaml:"sourcetype"`
+}
+
+type splunkNotifier struct {
+	name     string
+	cfg      *SplunkNotifierConfig
+	template TemplateConfig
+	client   *http.Client
+}
+
+func newSplunkNotifier(name string, cfg *SplunkNotifierConfig, template TemplateConfig) *splunkNotifier {
+	return &splunkNotifier{
+		name:     name,
+		cfg:      cfg,
+		template: template,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *splunkNotifier) Name() string {
+	return s.name
+}
+
+// splunkEvent is the HEC event envelope documented at
+// https://docs.splunk.com/Documentation/Splunk/latest/Data/FormateventsforHTTPEventCollector
+type splunkEvent struct {
+	Time       int64       `json:"time"`
+	Event      interface{} `json:"event"`
+	Index      string      `json:"index,omitempty"`
+	SourceType string      `json:"sourcetype,omitempty"`
+}
+
+func (s *splunkNotifier) Send(ctx context.Context, n Notification) error {
+	body, err := renderTemplate(s.template.Body, "{{.Message}}", n)
+	if err != nil {
+		return err
+	}
+
+	event := splunkEvent{
+		Time:       n.Timestamp.Unix(),
+		Index:      s.cfg.Index,
+		SourceType: s.cfg.SourceType,
+		Event: map[string]interface{}{
+			"rule":     n.Rule,
+			"message":  body,
+			"severity": n.Severity,
+			"labels":   n.Labels,
+		},
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshaling splunk event: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("error building splunk request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Splunk "+s.cfg.Token)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending splunk event: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("splunk HEC returned status %d", resp.StatusCode)
+	}
+	return nil
+}
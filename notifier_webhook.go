@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookNotifierConfig holds the settings for a `type: webhook` notifier
+// that POSTs a JSON payload to an arbitrary HTTP endpoint.
+type WebhookNotifierConfig struct {
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+	Timeout string            `yaml:"timeout"`
+}
+
+type webhookNotifier struct {
+	name     string
+	cfg      *WebhookNotifierConfig
+	template TemplateConfig
+	client   *http.Client
+}
+
+func newWebhookNotifier(name string, cfg *WebhookNotifierConfig, template TemplateConfig) *webhookNotifier {
+	return &webhookNotifier{
+		name:     name,
+		cfg:      cfg,
+		template: template,
+		client:   &http.Client{Timeout: parseDurationOrDefault(cfg.Timeout, 10*time.Second)},
+	}
+}
+
+func (w *webhookNotifier) Name() string {
+	return w.name
+}
+
+func (w *webhookNotifier) Send(ctx context.Context, n Notification) error {
+	body, err := renderTemplate(w.template.Body, "{{.Message}}", n)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"rule":      n.Rule,
+		"message":   body,
+		"severity":  n.Severity,
+		"timestamp": n.Timestamp,
+		"labels":    n.Labels,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling webhook payload: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("error building webhook request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending webhook request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
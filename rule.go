@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// severityKeywords classifies a matched log line into a coarse severity,
+// most specific first, used for the pattern_matches_total metric and for
+// notifier severity filtering.
+var severityKeywords = []struct {
+	pattern  *regexp.Regexp
+	severity string
+}{
+	{regexp.MustCompile(`(?i)critical|fatal|panic`), "critical"},
+	{regexp.MustCompile(`(?i)error|exception`), "error"},
+	{regexp.MustCompile(`(?i)warn`), "warning"},
+}
+
+func classifySeverity(line string) string {
+	for _, k := range severityKeywords {
+		if k.pattern.MatchString(line) {
+			return k.severity
+		}
+	}
+	return "info"
+}
+
+// compiledRule is a RuleConfig with its patterns and durations parsed, plus
+// the per-group state machine (Prometheus/Loki ruler style: a group must
+// match ForCount times inside the "for" window before it fires, and then
+// won't fire again for DedupWindow).
+type compiledRule struct {
+	cfg      RuleConfig
+	patterns []*regexp.Regexp
+
+	interval    time.Duration
+	dedupWindow time.Duration
+	forWindow   time.Duration
+	forCount    int
+
+	// cache deduplicates fired groups across restarts; occurrences (the
+	// "for" sliding window) stays in-memory since losing a few seconds of
+	// counting on restart is harmless.
+	cache Cache
+
+	mu          sync.Mutex
+	occurrences map[string][]time.Time
+}
+
+// compileRule validates and parses cfg into a compiledRule.
+func compileRule(cfg RuleConfig, cache Cache) (*compiledRule, error) {
+	if len(cfg.Patterns) == 0 {
+		return nil, fmt.Errorf("rule %q: at least one pattern is required", cfg.Name)
+	}
+
+	r := &compiledRule{
+		cfg:         cfg,
+		cache:       cache,
+		occurrences: make(map[string][]time.Time),
+	}
+
+	for _, p := range cfg.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: invalid pattern %q: %v", cfg.Name, p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	interval, err := time.ParseDuration(cfg.Interval)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: invalid interval: %v", cfg.Name, err)
+	}
+	r.interval = interval
+	r.dedupWindow = parseDurationOrDefault(cfg.DedupWindow, time.Hour)
+	r.forWindow = parseDurationOrDefault(cfg.For, 0)
+
+	r.forCount = cfg.ForCount
+	if r.forCount <= 0 {
+		r.forCount = 1
+	}
+
+	return r, nil
+}
+
+// matches reports whether line matches any of the rule's patterns.
+func (r *compiledRule) matches(line string) bool {
+	for _, p := range r.patterns {
+		if p.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupKey derives the bucket a log entry falls into from its labels,
+// according to the rule's group_by configuration.
+func (r *compiledRule) groupKey(labels map[string]string) string {
+	if len(r.cfg.GroupBy) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(r.cfg.GroupBy))
+	for _, key := range r.cfg.GroupBy {
+		parts = append(parts, key+"="+labels[key])
+	}
+	return strings.Join(parts, ",")
+}
+
+// evaluate records a matching log entry and reports whether its group
+// should fire now, along with how many occurrences are in the current
+// window.
+func (r *compiledRule) evaluate(e logEntry) (fire bool, count int) {
+	r.mu.Lock()
+	key := r.groupKey(e.Labels)
+	window := r.forWindow
+	if window <= 0 {
+		window = r.interval
+	}
+
+	occurrences := append(r.occurrences[key], e.Timestamp)
+	cutoff := e.Timestamp.Add(-window)
+	pruned := occurrences[:0]
+	for _, ts := range occurrences {
+		if ts.After(cutoff) {
+			pruned = append(pruned, ts)
+		}
+	}
+	if len(pruned) == 0 {
+		delete(r.occurrences, key)
+	} else {
+		r.occurrences[key] = pruned
+	}
+	r.mu.Unlock()
+
+	if len(pruned) < r.forCount {
+		return false, len(pruned)
+	}
+
+	// The dedup cache, not the in-memory occurrence map, decides whether
+	// this group has already fired recently, so that a restart doesn't
+	// cause a re-notification flood.
+	dedupKey := hashKey(r.cfg.Name + "|" + key)
+	if r.cache.Seen(dedupKey, r.dedupWindow, e.Timestamp) {
+		return false, len(pruned)
+	}
+
+	r.cache.Record(dedupKey, e.Timestamp)
+	return true, len(pruned)
+}
+
+// pruneStaleGroups drops any group_by bucket whose occurrences have all
+// aged out of the "for" window, so a rule grouping by a high-cardinality
+// label (pod name, instance, trace id) doesn't accumulate one dead map
+// entry per distinct label value forever. Unlike evaluate's own pruning,
+// this runs independently of new matches arriving, so it also catches
+// groups that simply stopped occurring (e.g. a pod that was redeployed).
+func (r *compiledRule) pruneStaleGroups(now time.Time) {
+	window := r.forWindow
+	if window <= 0 {
+		window = r.interval
+	}
+	cutoff := now.Add(-window)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, occurrences := range r.occurrences {
+		if len(occurrences) == 0 || occurrences[len(occurrences)-1].Before(cutoff) {
+			delete(r.occurrences, key)
+		}
+	}
+}
+
+// run evaluates the rule against Loki, either by polling on the rule's own
+// ticker (mode "poll", the default) or by streaming Loki's websocket tail
+// endpoint in real time (mode "tail"), until ctx is cancelled.
+func (r *compiledRule) run(ctx context.Context, endpoint, mode string, d *dispatcher, health *healthState) {
+	if mode == "tail" {
+		r.runTail(ctx, endpoint, d, health)
+		return
+	}
+	r.runPoll(ctx, endpoint, d, health)
+}
+
+// runPoll repeatedly queries Loki for the window since its last successful
+// query, dispatching a notification for every group that satisfies the
+// "for" and dedup rules. This has an inherent gap/overlap risk between
+// ticks that runTail avoids.
+func (r *compiledRule) runPoll(ctx context.Context, endpoint string, d *dispatcher, health *healthState) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			start := time.Now()
+			entries, err := queryLoki(ctx, endpoint, r.cfg.Query, last, now)
+			lokiQueryDuration.WithLabelValues(r.cfg.Name).Observe(time.Since(start).Seconds())
+			if err != nil {
+				lokiQueryErrors.WithLabelValues(r.cfg.Name).Inc()
+				log.Error().Err(err).Str("rule", r.cfg.Name).Msg("error querying Loki")
+				continue
+			}
+			last = now
+			health.recordSuccess(now)
+
+			for _, e := range entries {
+				r.handleEntry(e, d)
+			}
+		}
+	}
+}
+
+// runTail streams log lines from Loki's websocket tail endpoint as they
+// arrive, reconnecting with exponential backoff and resuming from the
+// timestamp of the last entry seen.
+func (r *compiledRule) runTail(ctx context.Context, endpoint string, d *dispatcher, health *healthState) {
+	backoff := time.Second
+	const maxBackoff = 30 * time.Second
+
+	last := time.Now()
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		connectedAt := time.Now()
+		err := tailLoki(ctx, endpoint, r.cfg.Query, last, func(e logEntry) {
+			health.recordSuccess(time.Now())
+			last = e.Timestamp
+			r.handleEntry(e, d)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		lokiQueryErrors.WithLabelValues(r.cfg.Name).Inc()
+		log.Error().Err(err).Str("rule", r.cfg.Name).Dur("backoff", backoff).Msg("Loki tail stream dropped, reconnecting")
+
+		// A connection that stayed up for a while before dropping isn't a
+		// sign of a persistent outage, so don't keep a backoff it never
+		// earned.
+		if time.Since(connectedAt) > maxBackoff {
+			backoff = time.Second
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// handleEntry applies the rule's pattern and "for"/dedup state machine to a
+// single log entry, dispatching a notification when it fires.
+func (r *compiledRule) handleEntry(e logEntry, d *dispatcher) {
+	if !r.matches(e.Line) {
+		return
+	}
+	severity := classifySeverity(e.Line)
+	patternMatches.WithLabelValues(r.cfg.Name, severity).Inc()
+
+	fire, count := r.evaluate(e)
+	if !fire {
+		return
+	}
+
+	d.Dispatch(Notification{
+		Rule:      r.cfg.Name,
+		Query:     r.cfg.Query,
+		Message:   fmt.Sprintf("[%s] %d matching log(s), most recently: %s", r.cfg.Name, count, e.Line),
+		Severity:  severity,
+		Timestamp: e.Timestamp,
+		Labels:    e.Labels,
+	})
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestRule(t *testing.T, cfg RuleConfig) *compiledRule {
+	t.Helper()
+	if cfg.Interval == "" {
+		cfg.Interval = "30s"
+	}
+	if len(cfg.Patterns) == 0 {
+		cfg.Patterns = []string{"error"}
+	}
+	r, err := compileRule(cfg, newMemoryCache())
+	if err != nil {
+		t.Fatalf("compileRule: %v", err)
+	}
+	return r
+}
+
+func TestRuleEvaluateForCount(t *testing.T) {
+	r := newTestRule(t, RuleConfig{Name: "r", For: "1m", ForCount: 3})
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		e := logEntry{Timestamp: now.Add(time.Duration(i) * time.Second)}
+		if fire, _ := r.evaluate(e); fire {
+			t.Fatalf("fired after only %d occurrences, want no fire before ForCount", i+1)
+		}
+	}
+
+	e := logEntry{Timestamp: now.Add(2 * time.Second)}
+	fire, count := r.evaluate(e)
+	if !fire {
+		t.Fatalf("did not fire on reaching ForCount")
+	}
+	if count != 3 {
+		t.Fatalf("count = %d, want 3", count)
+	}
+}
+
+func TestRuleEvaluateDedupSuppressesRefire(t *testing.T) {
+	r := newTestRule(t, RuleConfig{Name: "r", ForCount: 1, DedupWindow: "5m"})
+	now := time.Now()
+
+	if fire, _ := r.evaluate(logEntry{Timestamp: now}); !fire {
+		t.Fatalf("first match did not fire")
+	}
+	if fire, _ := r.evaluate(logEntry{Timestamp: now.Add(time.Minute)}); fire {
+		t.Fatalf("second match within dedup window fired again")
+	}
+	if fire, _ := r.evaluate(logEntry{Timestamp: now.Add(6 * time.Minute)}); !fire {
+		t.Fatalf("match after dedup window expired did not fire")
+	}
+}
+
+func TestRuleEvaluateGroupsAreIndependent(t *testing.T) {
+	r := newTestRule(t, RuleConfig{Name: "r", ForCount: 1, DedupWindow: "5m", GroupBy: []string{"job"}})
+	now := time.Now()
+
+	if fire, _ := r.evaluate(logEntry{Timestamp: now, Labels: map[string]string{"job": "a"}}); !fire {
+		t.Fatalf("group a did not fire on first match")
+	}
+	if fire, _ := r.evaluate(logEntry{Timestamp: now, Labels: map[string]string{"job": "b"}}); !fire {
+		t.Fatalf("group b was suppressed by group a's dedup state")
+	}
+}
+
+func TestRuleEvaluatePrunesOldOccurrences(t *testing.T) {
+	r := newTestRule(t, RuleConfig{Name: "r", For: "10s", ForCount: 2})
+	now := time.Now()
+
+	if fire, _ := r.evaluate(logEntry{Timestamp: now}); fire {
+		t.Fatalf("fired on a single occurrence with ForCount 2")
+	}
+	// This occurrence lands outside the first one's 10s window, so the
+	// count should reset to 1 rather than accumulate to 2.
+	if fire, count := r.evaluate(logEntry{Timestamp: now.Add(30 * time.Second)}); fire || count != 1 {
+		t.Fatalf("fire=%v count=%d, want fire=false count=1 once the first occurrence has aged out", fire, count)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	r := newTestRule(t, RuleConfig{Name: "r", Patterns: []string{"(?i)error", "panic"}})
+
+	if !r.matches("something errored") {
+		t.Fatalf("expected case-insensitive pattern to match")
+	}
+	if !r.matches("kernel panic") {
+		t.Fatalf("expected second pattern to match")
+	}
+	if r.matches("all good") {
+		t.Fatalf("unexpected match against a clean line")
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// tailResponse mirrors the JSON frames sent by Loki's
+// /loki/api/v1/tail websocket endpoint.
+type tailResponse struct {
+	Streams []struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	} `json:"streams"`
+}
+
+// tailLoki streams log lines from Loki's websocket tail endpoint, invoking
+// onEntry for each one, until ctx is cancelled or the connection drops. It
+// returns the error that ended the stream so the caller can decide whether
+// to reconnect.
+func tailLoki(ctx context.Context, endpoint, logql string, start time.Time, onEntry func(logEntry)) error {
+	wsURL, err := tailURL(endpoint, logql, start)
+	if err != nil {
+		return err
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		return fmt.Errorf("error dialing Loki tail endpoint: %v", err)
+	}
+	defer conn.Close()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("error reading from Loki tail stream: %v", err)
+		}
+
+		var resp tailResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return fmt.Errorf("error decoding Loki tail frame: %v", err)
+		}
+
+		for _, stream := range resp.Streams {
+			for _, value := range stream.Values {
+				ts, err := parseLokiTimestamp(value[0])
+				if err != nil {
+					ts = time.Now()
+				}
+				onEntry(logEntry{Line: value[1], Timestamp: ts, Labels: stream.Stream})
+			}
+		}
+	}
+}
+
+// tailURL converts endpoint into the Loki tail endpoint's ws(s):// URL,
+// carrying the LogQL query and the resume timestamp.
+func tailURL(endpoint, logql string, start time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("error parsing Loki endpoint: %v", err)
+	}
+
+	switch {
+	case strings.HasPrefix(u.Scheme, "https"):
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u.Path = strings.TrimRight(u.Path, "/") + "/loki/api/v1/tail"
+
+	q := u.Query()
+	q.Set("query", logql)
+	if !start.IsZero() {
+		q.Set("start", strconv.FormatInt(start.UnixNano(), 10))
+	}
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
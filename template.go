@@ -0,0 +1,26 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// renderTemplate executes tmplText (falling back to def when empty) against
+// data and returns the rendered string.
+func renderTemplate(tmplText, def string, data Notification) (string, error) {
+	if tmplText == "" {
+		tmplText = def
+	}
+
+	t, err := template.New("notification").Parse(tmplText)
+	if err != nil {
+		return "", fmt.Errorf("error parsing notification template: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("error rendering notification template: %v", err)
+	}
+	return buf.String(), nil
+}